@@ -0,0 +1,148 @@
+// Package cost shells out to Infracost to turn a Terraform plan into a cost
+// estimate and exposes assertions so tests can enforce real budget limits
+// instead of only checking that a plan succeeds.
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ResourceCost is a single line item from an Infracost breakdown.
+type ResourceCost struct {
+	Name          string  `json:"name"`
+	ResourceType  string  `json:"resourceType"`
+	MonthlyCost   float64 `json:"monthlyCost,string"`
+	HourlyCost    float64 `json:"hourlyCost,string"`
+}
+
+// Breakdown is the subset of `infracost breakdown --format json` this
+// package cares about.
+type Breakdown struct {
+	Projects []struct {
+		Breakdown struct {
+			Resources  []ResourceCost `json:"resources"`
+			TotalMonthlyCost float64 `json:"totalMonthlyCost,string"`
+		} `json:"breakdown"`
+	} `json:"projects"`
+	TotalMonthlyCost float64 `json:"totalMonthlyCost,string"`
+}
+
+// Resources flattens the per-project resource costs across all projects in
+// the breakdown.
+func (b *Breakdown) Resources() []ResourceCost {
+	var resources []ResourceCost
+	for _, p := range b.Projects {
+		resources = append(resources, p.Breakdown.Resources...)
+	}
+	return resources
+}
+
+// ResourceCostByAddress returns the cost entry matching resourceAddress
+// (e.g. "aws_db_instance.main"), or nil if it has no cost line item.
+func (b *Breakdown) ResourceCostByAddress(resourceAddress string) *ResourceCost {
+	for _, r := range b.Resources() {
+		if r.Name == resourceAddress {
+			return &r
+		}
+	}
+	return nil
+}
+
+// Options configures RunBreakdown.
+type Options struct {
+	// TerraformDir is the directory containing the Terraform module to price.
+	TerraformDir string
+	// VarFiles are passed through as --terraform-var-file flags.
+	VarFiles []string
+	// Vars are passed through as --terraform-var flags in "key=value" form.
+	Vars []string
+}
+
+// RunBreakdown runs `infracost breakdown --format json` against opts and
+// parses the result.
+func RunBreakdown(t *testing.T, opts Options) *Breakdown {
+	t.Helper()
+
+	args := []string{"breakdown", "--format", "json", "--path", opts.TerraformDir}
+	for _, f := range opts.VarFiles {
+		args = append(args, "--terraform-var-file", f)
+	}
+	for _, v := range opts.Vars {
+		args = append(args, "--terraform-var", v)
+	}
+
+	out, err := exec.Command("infracost", args...).Output()
+	require.NoError(t, err, "infracost breakdown failed")
+
+	var breakdown Breakdown
+	require.NoError(t, json.Unmarshal(out, &breakdown), "failed to parse infracost breakdown output")
+	return &breakdown
+}
+
+// AssertMonthlyCostBelow fails the test if the breakdown's total monthly
+// cost is not strictly below maxMonthlyCost (USD).
+func AssertMonthlyCostBelow(t *testing.T, breakdown *Breakdown, maxMonthlyCost float64) {
+	t.Helper()
+	assert.Less(t, breakdown.TotalMonthlyCost, maxMonthlyCost,
+		"total monthly cost $%.2f exceeds budget of $%.2f", breakdown.TotalMonthlyCost, maxMonthlyCost)
+}
+
+// AssertResourceCostBelow fails the test if resourceAddress is missing from
+// the breakdown or its monthly cost is not strictly below maxMonthlyCost.
+func AssertResourceCostBelow(t *testing.T, breakdown *Breakdown, resourceAddress string, maxMonthlyCost float64) {
+	t.Helper()
+	resource := breakdown.ResourceCostByAddress(resourceAddress)
+	require.NotNilf(t, resource, "resource %s not found in infracost breakdown", resourceAddress)
+	assert.Less(t, resource.MonthlyCost, maxMonthlyCost,
+		"%s monthly cost $%.2f exceeds budget of $%.2f", resourceAddress, resource.MonthlyCost, maxMonthlyCost)
+}
+
+// AssertNoUnexpectedResourceTypes fails the test if the breakdown contains
+// a priced resource type that is not present in allowedTypes.
+func AssertNoUnexpectedResourceTypes(t *testing.T, breakdown *Breakdown, allowedTypes []string) {
+	t.Helper()
+
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, rt := range allowedTypes {
+		allowed[rt] = true
+	}
+
+	var unexpected []string
+	for _, r := range breakdown.Resources() {
+		if !allowed[r.ResourceType] {
+			unexpected = append(unexpected, fmt.Sprintf("%s (%s)", r.Name, r.ResourceType))
+		}
+	}
+	assert.Empty(t, unexpected, "unexpected resource types found in cost breakdown: %v", unexpected)
+}
+
+// AssertCostIncreaseBelowBaseline compares breakdown against the baseline
+// JSON file at baselinePath and fails the test if the total monthly cost
+// increased by more than maxPercentIncrease percent.
+func AssertCostIncreaseBelowBaseline(t *testing.T, breakdown *Breakdown, baselinePath string, maxPercentIncrease float64) {
+	t.Helper()
+
+	baselineBytes, err := os.ReadFile(baselinePath)
+	require.NoError(t, err, "failed to read cost baseline %s", baselinePath)
+
+	var baseline Breakdown
+	require.NoError(t, json.Unmarshal(baselineBytes, &baseline), "failed to parse cost baseline %s", baselinePath)
+
+	if baseline.TotalMonthlyCost == 0 {
+		assert.LessOrEqual(t, breakdown.TotalMonthlyCost, 0.0,
+			"baseline cost is $0 but current plan costs $%.2f/mo", breakdown.TotalMonthlyCost)
+		return
+	}
+
+	percentIncrease := (breakdown.TotalMonthlyCost - baseline.TotalMonthlyCost) / baseline.TotalMonthlyCost * 100
+	assert.LessOrEqualf(t, percentIncrease, maxPercentIncrease,
+		"monthly cost increased %.1f%% ($%.2f -> $%.2f), exceeding the %.1f%% budget",
+		percentIncrease, baseline.TotalMonthlyCost, breakdown.TotalMonthlyCost, maxPercentIncrease)
+}
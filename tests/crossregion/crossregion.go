@@ -0,0 +1,178 @@
+// Package crossregion verifies that data tagged for disaster recovery
+// actually lands in a second AWS region, rather than trusting that
+// enable_cross_region_backup alone proves replication works.
+package crossregion
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/require"
+)
+
+// replicationPollInterval controls how often AssertCrossRegionReplication
+// polls S3 replication status while waiting for it to complete.
+const replicationPollInterval = 5 * time.Second
+
+// AssertCrossRegionReplication is the single entry point other tests use
+// to prove enable_cross_region_backup actually replicates data. It:
+//  1. lists RDS automated backups/snapshots in drRegion tagged projectTag
+//     and asserts at least one has SourceRegion == primaryRegion,
+//  2. verifies the uploads bucket has a replication configuration pointing
+//     at a DR bucket, and that the DR bucket uses SSE-KMS with a
+//     DR-region CMK,
+//  3. writes a test object to the primary bucket and polls until its
+//     replication status becomes COMPLETED, within timeout.
+func AssertCrossRegionReplication(t *testing.T, primaryRegion, drRegion, projectTag, uploadsBucket string, timeout time.Duration) {
+	t.Helper()
+
+	primarySess, err := session.NewSession(&awssdk.Config{Region: awssdk.String(primaryRegion)})
+	require.NoError(t, err)
+	drSess, err := session.NewSession(&awssdk.Config{Region: awssdk.String(drRegion)})
+	require.NoError(t, err)
+
+	assertDRBackupExists(t, rds.New(drSess), primaryRegion, projectTag)
+	drBucket := assertReplicationConfigured(t, s3.New(primarySess), s3.New(drSess), drRegion, uploadsBucket)
+	assertObjectReplicates(t, s3.New(primarySess), s3.New(drSess), uploadsBucket, drBucket, timeout)
+}
+
+// assertDRBackupExists lists RDS automated backups and snapshots in the DR
+// region filtered by projectTag and requires at least one whose
+// SourceRegion matches primaryRegion.
+func assertDRBackupExists(t *testing.T, drClient *rds.RDS, primaryRegion, projectTag string) {
+	t.Helper()
+	ctx := context.Background()
+
+	backups, err := drClient.DescribeDBInstanceAutomatedBackupsWithContext(ctx, &rds.DescribeDBInstanceAutomatedBackupsInput{})
+	require.NoError(t, err, "failed to list automated backups in DR region")
+
+	for _, b := range backups.DBInstanceAutomatedBackups {
+		if awssdk.StringValue(b.Region) != primaryRegion {
+			continue
+		}
+		if hasProjectTag(t, drClient, awssdk.StringValue(b.DBInstanceArn), projectTag) {
+			return
+		}
+	}
+
+	snapshots, err := drClient.DescribeDBSnapshotsWithContext(ctx, &rds.DescribeDBSnapshotsInput{})
+	require.NoError(t, err, "failed to list DB snapshots in DR region")
+
+	for _, s := range snapshots.DBSnapshots {
+		if awssdk.StringValue(s.SourceRegion) != primaryRegion {
+			continue
+		}
+		if hasProjectTag(t, drClient, awssdk.StringValue(s.DBSnapshotArn), projectTag) {
+			return
+		}
+	}
+
+	t.Fatalf("no RDS automated backup or snapshot tagged %s found in DR region with SourceRegion %s", projectTag, primaryRegion)
+}
+
+func hasProjectTag(t *testing.T, client *rds.RDS, resourceArn, projectTag string) bool {
+	t.Helper()
+	if resourceArn == "" {
+		return false
+	}
+
+	tags, err := client.ListTagsForResource(&rds.ListTagsForResourceInput{
+		ResourceName: awssdk.String(resourceArn),
+	})
+	require.NoError(t, err, "failed to list tags for %s", resourceArn)
+
+	for _, tag := range tags.TagList {
+		if awssdk.StringValue(tag.Key) == "Project" && awssdk.StringValue(tag.Value) == projectTag {
+			return true
+		}
+	}
+	return false
+}
+
+// assertReplicationConfigured verifies uploadsBucket has a replication
+// configuration pointing at a DR bucket, and that the DR bucket uses
+// SSE-KMS with a DR-region CMK. Returns the DR bucket name.
+func assertReplicationConfigured(t *testing.T, primaryClient, drClient *s3.S3, drRegion, uploadsBucket string) string {
+	t.Helper()
+
+	replication, err := primaryClient.GetBucketReplication(&s3.GetBucketReplicationInput{
+		Bucket: awssdk.String(uploadsBucket),
+	})
+	require.NoError(t, err, "failed to get replication configuration for %s", uploadsBucket)
+	require.NotEmpty(t, replication.ReplicationConfiguration.Rules, "bucket %s has no replication rules", uploadsBucket)
+
+	rule := replication.ReplicationConfiguration.Rules[0]
+	require.NotNil(t, rule.Destination, "replication rule for %s has no destination", uploadsBucket)
+
+	drBucketArn := awssdk.StringValue(rule.Destination.Bucket)
+	drBucket := arnToBucketName(drBucketArn)
+	require.NotEmpty(t, drBucket, "could not parse DR bucket name from destination ARN %s", drBucketArn)
+
+	encryption, err := drClient.GetBucketEncryption(&s3.GetBucketEncryptionInput{
+		Bucket: awssdk.String(drBucket),
+	})
+	require.NoError(t, err, "failed to get encryption configuration for DR bucket %s", drBucket)
+	require.NotEmpty(t, encryption.ServerSideEncryptionConfiguration.Rules)
+
+	rule0 := encryption.ServerSideEncryptionConfiguration.Rules[0]
+	require.NotNil(t, rule0.ApplyServerSideEncryptionByDefault)
+	require.Equal(t, s3.ServerSideEncryptionAwsKms, awssdk.StringValue(rule0.ApplyServerSideEncryptionByDefault.SSEAlgorithm),
+		"DR bucket %s must use SSE-KMS", drBucket)
+	require.Contains(t, awssdk.StringValue(rule0.ApplyServerSideEncryptionByDefault.KMSMasterKeyID), drRegion,
+		"DR bucket %s must be encrypted with a %s CMK", drBucket, drRegion)
+
+	return drBucket
+}
+
+// assertObjectReplicates writes a throwaway object to the primary bucket
+// and polls the primary bucket's replication status for that object until
+// it becomes COMPLETED, or fails after timeout.
+func assertObjectReplicates(t *testing.T, primaryClient, drClient *s3.S3, primaryBucket, drBucket string, timeout time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+
+	key := fmt.Sprintf("crossregion-replication-check/%d", time.Now().UnixNano())
+	_, err := primaryClient.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: awssdk.String(primaryBucket),
+		Key:    awssdk.String(key),
+		Body:   nil,
+	})
+	require.NoError(t, err, "failed to write replication test object to %s", primaryBucket)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		head, err := primaryClient.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+			Bucket: awssdk.String(primaryBucket),
+			Key:    awssdk.String(key),
+		})
+		if err == nil {
+			// S3 reports "COMPLETED" for a replicated source object; older
+			// SDK enums spell it "COMPLETE", so accept either.
+			status := awssdk.StringValue(head.ReplicationStatus)
+			if status == "COMPLETED" || status == s3.ReplicationStatusComplete {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("object %s/%s did not reach replication status COMPLETED within %s (DR bucket %s)", primaryBucket, key, timeout, drBucket)
+		}
+		time.Sleep(replicationPollInterval)
+	}
+}
+
+// arnToBucketName extracts the bucket name from an S3 bucket ARN
+// (arn:aws:s3:::bucket-name), or returns the input unchanged if it is
+// already a bare bucket name.
+func arnToBucketName(bucketArnOrName string) string {
+	const prefix = "arn:aws:s3:::"
+	if len(bucketArnOrName) > len(prefix) && bucketArnOrName[:len(prefix)] == prefix {
+		return bucketArnOrName[len(prefix):]
+	}
+	return bucketArnOrName
+}
@@ -0,0 +1,282 @@
+// Package drift detects infrastructure drift by diffing a Terraform plan's
+// resource_changes against the resources that actually exist in AWS for a
+// given project tag, classifying each difference as managed-out-of-band,
+// missing, or extraneous.
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// Kind classifies a single drifted resource.
+type Kind string
+
+const (
+	// ManagedOutOfBand means Terraform still knows about the resource but
+	// one or more of its attributes were changed outside of Terraform.
+	ManagedOutOfBand Kind = "managed-out-of-band"
+	// Missing means a resource Terraform expects to exist no longer
+	// exists in the cloud account (deleted out of band).
+	Missing Kind = "missing"
+	// Extraneous means a resource tagged for this project exists in the
+	// cloud account but is not managed by this Terraform state.
+	Extraneous Kind = "extraneous"
+)
+
+// Finding is a single drifted resource and why it was flagged.
+type Finding struct {
+	Kind    Kind
+	Address string
+	Detail  string
+}
+
+// Report groups drift Findings for a single Detect run.
+type Report struct {
+	Findings []Finding
+}
+
+// Empty reports whether no drift was found.
+func (r *Report) Empty() bool {
+	return len(r.Findings) == 0
+}
+
+// String renders the report as a per-resource diff summary.
+func (r *Report) String() string {
+	if r.Empty() {
+		return "no drift detected"
+	}
+
+	var out string
+	out += fmt.Sprintf("%d drifted resource(s):\n", len(r.Findings))
+	for _, f := range r.Findings {
+		out += fmt.Sprintf("  [%s] %s: %s\n", f.Kind, f.Address, f.Detail)
+	}
+	return out
+}
+
+// Options configures Detect.
+type Options struct {
+	// TerraformOptions points at the already-applied workspace to check.
+	TerraformOptions *terraform.Options
+	// AWSRegion is the region to query for live, project-tagged resources.
+	AWSRegion string
+	// ProjectTag is the value of the "Project" tag used to scope the live
+	// AWS resource lookup to this deployment.
+	ProjectTag string
+}
+
+// Detect runs `terraform plan -detailed-exitcode -refresh-only` followed by
+// `terraform plan -detailed-exitcode`, parses both plans' JSON output, and
+// cross-references resource_changes against live AWS resources tagged with
+// opts.ProjectTag to classify drift. It fails the test itself if either
+// plan returns exit code 2 (changes present) or the classified Report is
+// non-empty, so it is a self-contained, reusable entry point; it also
+// returns the Report so callers can log or further inspect it.
+func Detect(t *testing.T, opts Options) *Report {
+	t.Helper()
+
+	refreshExitCode, refreshPlan := planDetailed(t, opts.TerraformOptions, true)
+	regularExitCode, regularPlan := planDetailed(t, opts.TerraformOptions, false)
+
+	report := &Report{}
+	report.Findings = append(report.Findings, classifyPlanChanges(refreshPlan, ManagedOutOfBand)...)
+	report.Findings = append(report.Findings, classifyPlanChanges(regularPlan, ManagedOutOfBand)...)
+	report.Findings = append(report.Findings, classifyMissing(regularPlan)...)
+	report.Findings = append(report.Findings, classifyExtraneous(t, opts, regularPlan)...)
+
+	require.NotEqual(t, 2, refreshExitCode, "drift detected during refresh-only plan:\n%s", report)
+	require.NotEqual(t, 2, regularExitCode, "drift detected during plan:\n%s", report)
+	require.True(t, report.Empty(), "drift detected:\n%s", report)
+	return report
+}
+
+// planDetailed runs `terraform plan -detailed-exitcode` (optionally
+// -refresh-only), then `terraform show -json` on the resulting plan file,
+// returning the plan's exit code and parsed JSON document.
+func planDetailed(t *testing.T, options *terraform.Options, refreshOnly bool) (int, map[string]interface{}) {
+	t.Helper()
+
+	args := []string{"plan", "-detailed-exitcode", "-input=false", "-out=tfplan"}
+	if refreshOnly {
+		args = append(args, "-refresh-only")
+	}
+	for k, v := range options.Vars {
+		args = append(args, "-var", fmt.Sprintf("%s=%v", k, v))
+	}
+
+	cmd := exec.Command("terraform", args...)
+	cmd.Dir = options.TerraformDir
+	_ = cmd.Run()
+	exitCode := cmd.ProcessState.ExitCode()
+
+	showCmd := exec.Command("terraform", "show", "-json", "tfplan")
+	showCmd.Dir = options.TerraformDir
+	out, err := showCmd.Output()
+	require.NoError(t, err, "terraform show -json failed")
+
+	var plan map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &plan), "failed to parse terraform show -json output")
+
+	return exitCode, plan
+}
+
+// classifyPlanChanges flags every resource_changes entry whose action list
+// is neither ["no-op"] nor ["create"] (an update, replacement, delete or
+// read of a resource already in state) as the given kind.
+func classifyPlanChanges(plan map[string]interface{}, kind Kind) []Finding {
+	changes, _ := plan["resource_changes"].([]interface{})
+
+	var findings []Finding
+	for _, raw := range changes {
+		change, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		actions := actionList(change)
+		if isNoOp(actions) || isCreateOnly(actions) {
+			continue
+		}
+
+		address, _ := change["address"].(string)
+		findings = append(findings, Finding{
+			Kind:    kind,
+			Address: address,
+			Detail:  fmt.Sprintf("plan proposes actions %v outside of a deliberate apply", actions),
+		})
+	}
+	return findings
+}
+
+// classifyMissing flags resources whose only proposed action is "create",
+// meaning Terraform believes the resource no longer exists in the cloud
+// account and must be recreated.
+func classifyMissing(plan map[string]interface{}) []Finding {
+	changes, _ := plan["resource_changes"].([]interface{})
+
+	var findings []Finding
+	for _, raw := range changes {
+		change, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		actions := actionList(change)
+		if !isCreateOnly(actions) {
+			continue
+		}
+
+		address, _ := change["address"].(string)
+		findings = append(findings, Finding{
+			Kind:    Missing,
+			Address: address,
+			Detail:  "resource is in state but no longer exists in the cloud account",
+		})
+	}
+	return findings
+}
+
+// classifyExtraneous lists live AWS resources tagged with opts.ProjectTag
+// and flags any whose ARN does not correspond to a resource address known
+// to the plan, meaning it was created outside of Terraform.
+func classifyExtraneous(t *testing.T, opts Options, plan map[string]interface{}) []Finding {
+	t.Helper()
+
+	knownARNs := map[string]bool{}
+	changes, _ := plan["resource_changes"].([]interface{})
+	for _, raw := range changes {
+		change, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		inner, ok := change["change"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		after, ok := inner["after"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if arn, ok := after["arn"].(string); ok {
+			knownARNs[arn] = true
+		}
+	}
+
+	sess, err := session.NewSession(&awssdk.Config{Region: awssdk.String(opts.AWSRegion)})
+	require.NoError(t, err)
+
+	client := resourcegroupstaggingapi.New(sess)
+	ctx := context.Background()
+
+	var findings []Finding
+	err = client.GetResourcesPagesWithContext(ctx, &resourcegroupstaggingapi.GetResourcesInput{
+		TagFilters: []*resourcegroupstaggingapi.TagFilter{
+			{Key: awssdk.String("Project"), Values: []*string{awssdk.String(opts.ProjectTag)}},
+		},
+	}, func(page *resourcegroupstaggingapi.GetResourcesOutput, lastPage bool) bool {
+		for _, mapping := range page.ResourceTagMappingList {
+			arn := awssdk.StringValue(mapping.ResourceARN)
+			if !knownARNs[arn] {
+				findings = append(findings, Finding{
+					Kind:    Extraneous,
+					Address: arn,
+					Detail:  fmt.Sprintf("tagged Project=%s but not present in terraform plan", opts.ProjectTag),
+				})
+			}
+		}
+		return true
+	})
+	require.NoError(t, err, "failed to list tagged AWS resources for drift comparison")
+
+	return findings
+}
+
+func actionList(change map[string]interface{}) []string {
+	inner, ok := change["change"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	rawActions, ok := inner["actions"].([]interface{})
+	if !ok {
+		return nil
+	}
+	actions := make([]string, 0, len(rawActions))
+	for _, a := range rawActions {
+		if s, ok := a.(string); ok {
+			actions = append(actions, s)
+		}
+	}
+	return actions
+}
+
+func isNoOp(actions []string) bool {
+	return len(actions) == 1 && actions[0] == "no-op"
+}
+
+func isCreateOnly(actions []string) bool {
+	return len(actions) == 1 && actions[0] == "create"
+}
+
+// SkipApplyEnvVar is the environment variable that, when set to "1", tells
+// a drift test to skip apply/destroy and run standalone against an
+// already-deployed environment (e.g. the real production workspace in a
+// nightly CI job).
+const SkipApplyEnvVar = "TF_DRIFT_ONLY"
+
+// SkipApply reports whether SkipApplyEnvVar is set, meaning Detect should
+// be run against an already-deployed workspace instead of applying/destroying
+// a fresh one.
+func SkipApply() bool {
+	return os.Getenv(SkipApplyEnvVar) == "1"
+}
@@ -0,0 +1,152 @@
+// Package policy evaluates Terraform plan JSON against a bundle of Rego
+// policies and reports violations grouped by resource address.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// Violation describes a single Rego deny rule that fired for a resource.
+type Violation struct {
+	ResourceAddress string
+	ResourceType    string
+	Policy          string
+	Message         string
+}
+
+// Report groups violations by resource address so callers can print a
+// readable summary instead of one line per failed rule.
+type Report struct {
+	Violations []Violation
+}
+
+// Empty reports whether no policy violations were found.
+func (r *Report) Empty() bool {
+	return len(r.Violations) == 0
+}
+
+// String renders the report as a grouped, human-readable summary.
+func (r *Report) String() string {
+	if r.Empty() {
+		return "no policy violations"
+	}
+
+	byResource := map[string][]Violation{}
+	var addresses []string
+	for _, v := range r.Violations {
+		if _, ok := byResource[v.ResourceAddress]; !ok {
+			addresses = append(addresses, v.ResourceAddress)
+		}
+		byResource[v.ResourceAddress] = append(byResource[v.ResourceAddress], v)
+	}
+	sort.Strings(addresses)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d polic%s violation(s) across %d resource(s):\n", len(r.Violations), plural(len(r.Violations)), len(addresses))
+	for _, addr := range addresses {
+		fmt.Fprintf(&b, "  %s:\n", addr)
+		for _, v := range byResource[addr] {
+			fmt.Fprintf(&b, "    - [%s] %s\n", v.Policy, v.Message)
+		}
+	}
+	return b.String()
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
+
+// Evaluator evaluates `data.terraform.deny` against Terraform plan resource
+// changes for a bundle of Rego policies loaded from disk.
+type Evaluator struct {
+	query rego.PreparedEvalQuery
+}
+
+// NewEvaluator compiles the Rego policies found under policyDir (typically
+// "../policies" relative to the calling test package) into an Evaluator.
+func NewEvaluator(ctx context.Context, policyDir string) (*Evaluator, error) {
+	r := rego.New(
+		rego.Query("data.terraform.deny"),
+		rego.Load([]string{policyDir}, regoFilesOnly),
+	)
+
+	query, err := r.PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("policy: failed to compile rego bundle in %s: %w", policyDir, err)
+	}
+	return &Evaluator{query: query}, nil
+}
+
+func regoFilesOnly(path string, info fs.FileInfo, depth int) bool {
+	if info.IsDir() {
+		return false
+	}
+	return !strings.HasSuffix(path, ".rego")
+}
+
+// EvaluateResourceChanges evaluates every resource_changes entry of a
+// `terraform show -json` plan document against the loaded policies and
+// returns a Report of all deny messages produced.
+func (e *Evaluator) EvaluateResourceChanges(ctx context.Context, plan map[string]interface{}) (*Report, error) {
+	changes, _ := plan["resource_changes"].([]interface{})
+
+	report := &Report{}
+	for _, raw := range changes {
+		change, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		results, err := e.query.Eval(ctx, rego.EvalInput(change))
+		if err != nil {
+			return nil, fmt.Errorf("policy: eval failed for %v: %w", change["address"], err)
+		}
+
+		address, _ := change["address"].(string)
+		resourceType, _ := change["type"].(string)
+
+		for _, result := range results {
+			for _, expr := range result.Expressions {
+				denies, ok := expr.Value.([]interface{})
+				if !ok {
+					continue
+				}
+				for _, d := range denies {
+					policyName, message := splitDenyMessage(d)
+					report.Violations = append(report.Violations, Violation{
+						ResourceAddress: address,
+						ResourceType:    resourceType,
+						Policy:          policyName,
+						Message:         message,
+					})
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// splitDenyMessage accepts a {"policy": "...", "msg": "..."} deny object,
+// as produced by every bundled policy in tests/policies/, and normalizes it
+// to (policy, msg).
+func splitDenyMessage(d interface{}) (string, string) {
+	v, ok := d.(map[string]interface{})
+	if !ok {
+		return "terraform.deny", fmt.Sprintf("%v", d)
+	}
+	policyName, _ := v["policy"].(string)
+	msg, _ := v["msg"].(string)
+	if policyName == "" {
+		policyName = "terraform.deny"
+	}
+	return policyName, msg
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+
+	"github.com/hoangsonww/petswipe-match-app/tests/crossregion"
+)
+
+// crossRegionReplicationTimeout bounds how long
+// TestTerraformCrossRegionBackupReplication waits for S3 replication to
+// reach COMPLETED for the object it writes during the test.
+const crossRegionReplicationTimeout = 10 * time.Minute
+
+// TestTerraformCrossRegionBackupReplication proves that
+// enable_cross_region_backup actually lands data in a second region,
+// rather than only asserting that the plan with that flag set succeeds
+// (as TestTerraformModuleDisasterRecovery does). It applies the module
+// with cross-region backup enabled and calls
+// crossregion.AssertCrossRegionReplication to verify RDS backups, bucket
+// replication configuration, and end-to-end object replication.
+func TestTerraformCrossRegionBackupReplication(t *testing.T) {
+	t.Parallel()
+
+	primaryRegion := "us-east-1"
+	drRegion := "us-west-2"
+	uniqueID := random.UniqueId()
+	projectName := fmt.Sprintf("petswipe-dr-cr-%s", uniqueID)
+
+	terraformOptions := &terraform.Options{
+		TerraformDir: "../../terraform",
+		Vars: map[string]interface{}{
+			"project":                    projectName,
+			"environment":                "test",
+			"aws_region":                 primaryRegion,
+			"db_backup_retention_period": 30,
+			"enable_cross_region_backup": true,
+		},
+		MaxRetries:         3,
+		TimeBetweenRetries: 5 * time.Second,
+	}
+
+	defer terraform.Destroy(t, terraformOptions)
+	terraform.InitAndApply(t, terraformOptions)
+
+	uploadsBucket := terraform.Output(t, terraformOptions, "s3_uploads_bucket")
+
+	crossregion.AssertCrossRegionReplication(t, primaryRegion, drRegion, projectName, uploadsBucket, crossRegionReplicationTimeout)
+}
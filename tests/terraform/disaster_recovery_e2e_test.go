@@ -0,0 +1,269 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drSLAThresholds are the timeout/SLA parameters TestTerraformDisasterRecoveryE2E
+// asserts against. They are exposed as a struct (rather than hard-coded
+// sleeps) so the same harness can be reused with tighter thresholds for PR
+// smoke runs and looser thresholds for nightly long-running jobs.
+type drSLAThresholds struct {
+	// FailoverSLA is the max time a Multi-AZ RDS forced failover may take.
+	FailoverSLA time.Duration
+	// ECSReconvergeSLA is the max time an ECS service may take to restore
+	// its desired task count after a task is killed.
+	ECSReconvergeSLA time.Duration
+	// RestoreSLA is the max time a point-in-time-restore may take to reach
+	// the "available" state.
+	RestoreSLA time.Duration
+	// PollInterval is how often status is polled while waiting on an SLA.
+	PollInterval time.Duration
+}
+
+// defaultPRSmokeSLA is tuned for fast PR feedback; nightly jobs should pass
+// a looser drSLAThresholds (e.g. 15m/10m/30m) into the same harness.
+var defaultPRSmokeSLA = drSLAThresholds{
+	FailoverSLA:      3 * time.Minute,
+	ECSReconvergeSLA: 2 * time.Minute,
+	RestoreSLA:       15 * time.Minute,
+	PollInterval:     10 * time.Second,
+}
+
+// TestTerraformDisasterRecoveryE2E proves that disaster recovery actually
+// works, rather than only checking that `terraform plan` succeeds like
+// TestTerraformModuleDisasterRecovery does. After applying the module it:
+//  1. takes a manual RDS snapshot of the Multi-AZ instance,
+//  2. forces an AZ failover via RebootDBInstance(ForceFailover=true) and
+//     asserts it completes within SLA,
+//  3. kills a running ECS task and asserts the service re-converges to its
+//     desired count within SLA,
+//  4. performs a point-in-time restore to a throwaway instance and asserts
+//     it reaches "available", then tears the restored instance down.
+func TestTerraformDisasterRecoveryE2E(t *testing.T) {
+	t.Parallel()
+
+	awsRegion := "us-east-1"
+	uniqueID := random.UniqueId()
+	projectName := fmt.Sprintf("petswipe-dr-%s", uniqueID)
+
+	terraformOptions := &terraform.Options{
+		TerraformDir: "../../terraform",
+		Vars: map[string]interface{}{
+			"project":                       projectName,
+			"environment":                   "test",
+			"aws_region":                    awsRegion,
+			"db_multi_az":                   true,
+			"db_backup_retention_period":    30,
+			"enable_point_in_time_recovery": true,
+			"enable_cross_region_backup":    true,
+		},
+		MaxRetries:         3,
+		TimeBetweenRetries: 5 * time.Second,
+	}
+
+	defer terraform.Destroy(t, terraformOptions)
+	terraform.InitAndApply(t, terraformOptions)
+
+	sess, err := session.NewSession(&awssdk.Config{Region: awssdk.String(awsRegion)})
+	require.NoError(t, err)
+
+	dbInstanceID := terraform.Output(t, terraformOptions, "rds_instance_id")
+	require.NotEmpty(t, dbInstanceID)
+
+	clusterName := terraform.Output(t, terraformOptions, "ecs_cluster_name")
+	serviceName := terraform.Output(t, terraformOptions, "ecs_service_name")
+	require.NotEmpty(t, clusterName)
+	require.NotEmpty(t, serviceName)
+
+	sla := defaultPRSmokeSLA
+
+	t.Run("ManualSnapshot", func(t *testing.T) {
+		testManualSnapshot(t, rds.New(sess), dbInstanceID)
+	})
+
+	t.Run("AZFailover", func(t *testing.T) {
+		testAZFailover(t, rds.New(sess), dbInstanceID, sla)
+	})
+
+	t.Run("ECSTaskRecovery", func(t *testing.T) {
+		testECSTaskRecovery(t, ecs.New(sess), clusterName, serviceName, sla)
+	})
+
+	t.Run("PointInTimeRestore", func(t *testing.T) {
+		testPointInTimeRestore(t, rds.New(sess), dbInstanceID, sla)
+	})
+}
+
+// testManualSnapshot takes a manual RDS snapshot and waits for it to become
+// available, proving backups can actually be created on demand.
+func testManualSnapshot(t *testing.T, client *rds.RDS, dbInstanceID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	snapshotID := fmt.Sprintf("%s-manual-%s", dbInstanceID, random.UniqueId())
+	_, err := client.CreateDBSnapshotWithContext(ctx, &rds.CreateDBSnapshotInput{
+		DBInstanceIdentifier: awssdk.String(dbInstanceID),
+		DBSnapshotIdentifier: awssdk.String(snapshotID),
+	})
+	require.NoError(t, err, "failed to create manual RDS snapshot")
+
+	err = client.WaitUntilDBSnapshotAvailableWithContext(ctx, &rds.DescribeDBSnapshotsInput{
+		DBSnapshotIdentifier: awssdk.String(snapshotID),
+	})
+	assert.NoError(t, err, "manual snapshot %s did not become available", snapshotID)
+
+	_, _ = client.DeleteDBSnapshotWithContext(ctx, &rds.DeleteDBSnapshotInput{
+		DBSnapshotIdentifier: awssdk.String(snapshotID),
+	})
+}
+
+// testAZFailover simulates an AZ failure by forcing a Multi-AZ failover and
+// asserts the instance returns to "available" within sla.FailoverSLA.
+func testAZFailover(t *testing.T, client *rds.RDS, dbInstanceID string, sla drSLAThresholds) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := client.RebootDBInstanceWithContext(ctx, &rds.RebootDBInstanceInput{
+		DBInstanceIdentifier: awssdk.String(dbInstanceID),
+		ForceFailover:        awssdk.Bool(true),
+	})
+	require.NoError(t, err, "failed to force AZ failover")
+
+	// A failover is async: the very first describe call can still report
+	// "available" before RDS has actually started failing over. Require
+	// observing the instance leave its steady state before accepting a
+	// later "available" as proof the failover happened, rather than
+	// passing on a no-op.
+	deadline := time.Now().Add(sla.FailoverSLA)
+	leftSteadyState := false
+	for {
+		out, err := client.DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{
+			DBInstanceIdentifier: awssdk.String(dbInstanceID),
+		})
+		require.NoError(t, err)
+		require.Len(t, out.DBInstances, 1)
+
+		status := awssdk.StringValue(out.DBInstances[0].DBInstanceStatus)
+		switch {
+		case !leftSteadyState && status != "available":
+			leftSteadyState = true
+		case leftSteadyState && status == "available":
+			return
+		}
+
+		if time.Now().After(deadline) {
+			if !leftSteadyState {
+				t.Fatalf("AZ failover for %s never left the \"available\" state within SLA of %s; failover may not have been triggered", dbInstanceID, sla.FailoverSLA)
+			}
+			t.Fatalf("AZ failover for %s did not complete within SLA of %s", dbInstanceID, sla.FailoverSLA)
+		}
+		time.Sleep(sla.PollInterval)
+	}
+}
+
+// testECSTaskRecovery deletes a running ECS task and asserts the service
+// re-converges to its desired task count within sla.ECSReconvergeSLA.
+func testECSTaskRecovery(t *testing.T, client *ecs.ECS, clusterName, serviceName string, sla drSLAThresholds) {
+	t.Helper()
+	ctx := context.Background()
+
+	tasks, err := client.ListTasksWithContext(ctx, &ecs.ListTasksInput{
+		Cluster:     awssdk.String(clusterName),
+		ServiceName: awssdk.String(serviceName),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, tasks.TaskArns, "no running tasks found for service %s", serviceName)
+
+	_, err = client.StopTaskWithContext(ctx, &ecs.StopTaskInput{
+		Cluster: awssdk.String(clusterName),
+		Task:    tasks.TaskArns[0],
+		Reason:  awssdk.String("chaos test: simulated task failure"),
+	})
+	require.NoError(t, err, "failed to stop ECS task")
+
+	// StopTask is async: the first describe call can still report
+	// RunningCount == DesiredCount before ECS has noticed the task died.
+	// Require observing the service actually dip below its desired count
+	// before accepting a later re-convergence as proof recovery happened,
+	// rather than passing on a no-op.
+	deadline := time.Now().Add(sla.ECSReconvergeSLA)
+	observedDegraded := false
+	for {
+		out, err := client.DescribeServicesWithContext(ctx, &ecs.DescribeServicesInput{
+			Cluster:  awssdk.String(clusterName),
+			Services: []*string{awssdk.String(serviceName)},
+		})
+		require.NoError(t, err)
+		require.Len(t, out.Services, 1)
+
+		svc := out.Services[0]
+		running := awssdk.Int64Value(svc.RunningCount)
+		desired := awssdk.Int64Value(svc.DesiredCount)
+
+		switch {
+		case !observedDegraded && running < desired:
+			observedDegraded = true
+		case observedDegraded && running == desired:
+			return
+		}
+
+		if time.Now().After(deadline) {
+			if !observedDegraded {
+				t.Fatalf("service %s never dropped below its desired count of %d within SLA of %s; task stop may not have been observed", serviceName, desired, sla.ECSReconvergeSLA)
+			}
+			t.Fatalf("service %s did not re-converge to desired count %d within SLA of %s", serviceName, desired, sla.ECSReconvergeSLA)
+		}
+		time.Sleep(sla.PollInterval)
+	}
+}
+
+// testPointInTimeRestore restores dbInstanceID to a throwaway instance and
+// asserts it reaches "available" within sla.RestoreSLA, then destroys it.
+func testPointInTimeRestore(t *testing.T, client *rds.RDS, dbInstanceID string, sla drSLAThresholds) {
+	t.Helper()
+	ctx := context.Background()
+
+	restoredID := fmt.Sprintf("%s-pitr-%s", dbInstanceID, random.UniqueId())
+	_, err := client.RestoreDBInstanceToPointInTimeWithContext(ctx, &rds.RestoreDBInstanceToPointInTimeInput{
+		SourceDBInstanceIdentifier: awssdk.String(dbInstanceID),
+		TargetDBInstanceIdentifier: awssdk.String(restoredID),
+		UseLatestRestorableTime:   awssdk.Bool(true),
+	})
+	require.NoError(t, err, "failed to start point-in-time restore")
+
+	defer func() {
+		_, _ = client.DeleteDBInstanceWithContext(ctx, &rds.DeleteDBInstanceInput{
+			DBInstanceIdentifier:   awssdk.String(restoredID),
+			SkipFinalSnapshot:      awssdk.Bool(true),
+			DeleteAutomatedBackups: awssdk.Bool(true),
+		})
+	}()
+
+	deadline := time.Now().Add(sla.RestoreSLA)
+	for {
+		out, err := client.DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{
+			DBInstanceIdentifier: awssdk.String(restoredID),
+		})
+		if err == nil && len(out.DBInstances) == 1 &&
+			awssdk.StringValue(out.DBInstances[0].DBInstanceStatus) == "available" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("restored instance %s did not reach available within SLA of %s", restoredID, sla.RestoreSLA)
+		}
+		time.Sleep(sla.PollInterval)
+	}
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+
+	"github.com/hoangsonww/petswipe-match-app/tests/drift"
+)
+
+// TestTerraformNoDrift applies the module (unless TF_DRIFT_ONLY=1, in which
+// case it targets the already-deployed workspace at terraformOptions.TerraformDir
+// and skips apply/destroy) and asserts drift.Detect finds no drift. It is
+// meant to run both as a post-apply check in the normal test suite and,
+// standalone with TF_DRIFT_ONLY=1, as a nightly CI job against the real
+// production workspace.
+func TestTerraformNoDrift(t *testing.T) {
+	if !drift.SkipApply() {
+		t.Parallel()
+	}
+
+	awsRegion := "us-east-1"
+	projectName := "petswipe-prod"
+
+	terraformOptions := &terraform.Options{
+		TerraformDir: "../../terraform",
+		Vars: map[string]interface{}{
+			"aws_region": awsRegion,
+		},
+	}
+
+	if !drift.SkipApply() {
+		uniqueID := random.UniqueId()
+		projectName = fmt.Sprintf("petswipe-drift-%s", uniqueID)
+		terraformOptions.Vars["project"] = projectName
+		terraformOptions.MaxRetries = 3
+		terraformOptions.TimeBetweenRetries = 5 * time.Second
+
+		defer terraform.Destroy(t, terraformOptions)
+		terraform.InitAndApply(t, terraformOptions)
+	} else {
+		terraform.Init(t, terraformOptions)
+	}
+
+	drift.Detect(t, drift.Options{
+		TerraformOptions: terraformOptions,
+		AWSRegion:        awsRegion,
+		ProjectTag:       projectName,
+	})
+}
@@ -9,9 +9,44 @@ import (
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/hoangsonww/petswipe-match-app/tests/cost"
+	"github.com/hoangsonww/petswipe-match-app/tests/terratest/steps"
 )
 
-// TestTerraformInfrastructure validates the complete infrastructure deployment
+// costBaselinePath is the checked-in Infracost baseline that
+// TestTerraformModuleCostOptimization diffs the current branch against.
+const costBaselinePath = "../cost/baseline_cost.json"
+
+// maxCostIncreasePercent is the largest allowed increase in total monthly
+// cost, relative to costBaselinePath, before a PR fails this test.
+const maxCostIncreasePercent = 10.0
+
+// allowedCostedResourceTypes are the resource types this module is expected
+// to provision. Anything else appearing in the Infracost breakdown likely
+// indicates an unintended resource was added.
+var allowedCostedResourceTypes = []string{
+	"aws_db_instance",
+	"aws_ecs_service",
+	"aws_nat_gateway",
+	"aws_s3_bucket",
+	"aws_cloudwatch_log_group",
+	"aws_kms_key",
+}
+
+// TestTerraformInfrastructure validates the complete infrastructure
+// deployment across its update path, not just a single apply. It declares
+// an ordered steps.Step matrix against one persistent workspace:
+//  1. InitialDeploy - deploys with ecs_min_capacity=1 and runs the full
+//     resource-verification suite that used to be the whole test.
+//  2. ScaleUp - raises ecs_min_capacity to 3 and asserts the autoscaling
+//     target updates in place rather than being replaced.
+//  3. ToggleKMSEncryption - flips enable_kms_encryption and asserts the
+//     KMS key is recreated (or torn down) rather than silently unchanged.
+//
+// Each step's apply is followed by an empty-plan assertion, so update-path
+// bugs that a one-shot apply can't see (drift, unwanted replacement) fail
+// the test immediately.
 func TestTerraformInfrastructure(t *testing.T) {
 	t.Parallel()
 
@@ -24,142 +59,128 @@ func TestTerraformInfrastructure(t *testing.T) {
 	terraformOptions := &terraform.Options{
 		TerraformDir: "../../terraform",
 		Vars: map[string]interface{}{
-			"project":                 projectName,
-			"environment":             "test",
-			"aws_region":              awsRegion,
-			"vpc_id":                  "vpc-test",
-			"subnet_ids":              []string{"subnet-test1", "subnet-test2"},
-			"security_group_ids":      []string{"sg-test"},
-			"db_username":             "testuser",
-			"db_password":             "TestPassword123!",
-			"enable_kms_encryption":   true,
-			"enable_service_mesh":     true,
-			"enable_gitops":           true,
-			"db_multi_az":             false, // Disable multi-AZ for testing
-			"ecs_min_capacity":        1,
-			"ecs_max_capacity":        2,
-			"ecs_business_hours_min":  2,
-			"ecs_business_hours_max":  4,
+			"project":                projectName,
+			"environment":            "test",
+			"aws_region":             awsRegion,
+			"vpc_id":                 "vpc-test",
+			"subnet_ids":             []string{"subnet-test1", "subnet-test2"},
+			"security_group_ids":     []string{"sg-test"},
+			"db_username":            "testuser",
+			"db_password":            "TestPassword123!",
+			"enable_kms_encryption":  true,
+			"enable_service_mesh":    true,
+			"enable_gitops":          true,
+			"db_multi_az":            false, // Disable multi-AZ for testing
+			"ecs_min_capacity":       1,
+			"ecs_max_capacity":       2,
+			"ecs_business_hours_min": 2,
+			"ecs_business_hours_max": 4,
 		},
 		MaxRetries:         3,
 		TimeBetweenRetries: 5 * time.Second,
 	}
 
-	// Cleanup resources after test
-	defer terraform.Destroy(t, terraformOptions)
-
-	// Initialize and apply Terraform
-	terraform.InitAndApply(t, terraformOptions)
-
-	// Test 1: Verify RDS instance is created
-	t.Run("VerifyRDSInstance", func(t *testing.T) {
-		dbInstanceID := terraform.Output(t, terraformOptions, "rds_instance_id")
-		assert.NotEmpty(t, dbInstanceID)
-
-		// Verify RDS instance exists and is in available state
-		dbInstance := aws.GetRdsInstanceDetails(t, dbInstanceID, awsRegion)
-		assert.Equal(t, "available", *dbInstance.DBInstanceStatus)
-		assert.Equal(t, true, *dbInstance.StorageEncrypted)
-	})
-
-	// Test 2: Verify ECS cluster is created
-	t.Run("VerifyECSCluster", func(t *testing.T) {
-		clusterName := terraform.Output(t, terraformOptions, "ecs_cluster_name")
-		assert.NotEmpty(t, clusterName)
-		assert.Contains(t, clusterName, projectName)
-	})
-
-	// Test 3: Verify S3 buckets are created with proper configuration
-	t.Run("VerifyS3Buckets", func(t *testing.T) {
-		staticBucket := terraform.Output(t, terraformOptions, "s3_static_bucket")
-		uploadsBucket := terraform.Output(t, terraformOptions, "s3_uploads_bucket")
-
-		assert.NotEmpty(t, staticBucket)
-		assert.NotEmpty(t, uploadsBucket)
-
-		// Verify bucket versioning
-		versioning := aws.GetS3BucketVersioning(t, awsRegion, uploadsBucket)
-		assert.Equal(t, "Enabled", versioning)
-	})
-
-	// Test 4: Verify ECR repositories
-	t.Run("VerifyECRRepositories", func(t *testing.T) {
-		backendRepo := terraform.Output(t, terraformOptions, "ecr_backend_url")
-		frontendRepo := terraform.Output(t, terraformOptions, "ecr_frontend_url")
-
-		assert.NotEmpty(t, backendRepo)
-		assert.NotEmpty(t, frontendRepo)
-		assert.Contains(t, backendRepo, "backend")
-		assert.Contains(t, frontendRepo, "frontend")
-	})
-
-	// Test 5: Verify KMS key configuration
-	t.Run("VerifyKMSKey", func(t *testing.T) {
-		kmsKeyID := terraform.Output(t, terraformOptions, "kms_key_id")
-		assert.NotEmpty(t, kmsKeyID)
-
-		// Verify key rotation is enabled
-		keyMetadata := aws.GetKmsKeyMetadata(t, awsRegion, kmsKeyID)
-		assert.Equal(t, true, *keyMetadata.KeyRotationEnabled)
-	})
-
-	// Test 6: Verify CloudWatch log groups
-	t.Run("VerifyCloudWatchLogs", func(t *testing.T) {
-		logGroupName := terraform.Output(t, terraformOptions, "cloudwatch_log_group")
-		assert.NotEmpty(t, logGroupName)
-
-		// Verify retention period
-		logGroup := aws.GetCloudWatchLogGroup(t, awsRegion, logGroupName)
-		assert.NotNil(t, logGroup.RetentionInDays)
-	})
-
-	// Test 7: Verify autoscaling configuration
-	t.Run("VerifyAutoscaling", func(t *testing.T) {
-		autoscalingTargetID := terraform.Output(t, terraformOptions, "autoscaling_target_id")
-		assert.NotEmpty(t, autoscalingTargetID)
-	})
-
-	// Test 8: Verify App Mesh resources (if enabled)
-	t.Run("VerifyAppMesh", func(t *testing.T) {
-		meshID := terraform.Output(t, terraformOptions, "appmesh_mesh_id")
-		if meshID != "" {
-			assert.Contains(t, meshID, projectName)
-		}
-	})
-
-	// Test 9: Verify security configurations
-	t.Run("VerifySecurityConfig", func(t *testing.T) {
-		secretArn := terraform.Output(t, terraformOptions, "db_credentials_secret_arn")
-		assert.NotEmpty(t, secretArn)
-		assert.Contains(t, secretArn, "secretsmanager")
-	})
-
-	// Test 10: Verify monitoring and alarms
-	t.Run("VerifyMonitoring", func(t *testing.T) {
-		dashboardName := terraform.Output(t, terraformOptions, "cloudwatch_dashboard_name")
-		assert.NotEmpty(t, dashboardName)
-		assert.Contains(t, dashboardName, "overview")
-	})
-}
-
-// TestTerraformModuleSecurity validates security configurations
-func TestTerraformModuleSecurity(t *testing.T) {
-	t.Parallel()
-
-	terraformOptions := &terraform.Options{
-		TerraformDir: "../../terraform",
-		Vars: map[string]interface{}{
-			"enable_waf":              true,
-			"enable_kms_encryption":   true,
-			"enable_guardduty":        true,
+	var previousAutoscalingTargetID string
+	var previousKMSKeyID string
+
+	steps.Run(t, terraformOptions, []steps.Step{
+		{
+			Name: "InitialDeploy",
+			Vars: map[string]interface{}{"ecs_min_capacity": 1},
+			Checks: []steps.CheckFunc{
+				func(t *testing.T, o *terraform.Options) {
+					dbInstanceID := terraform.Output(t, o, "rds_instance_id")
+					assert.NotEmpty(t, dbInstanceID)
+
+					dbInstance := aws.GetRdsInstanceDetails(t, dbInstanceID, awsRegion)
+					assert.Equal(t, "available", *dbInstance.DBInstanceStatus)
+					assert.Equal(t, true, *dbInstance.StorageEncrypted)
+				},
+				func(t *testing.T, o *terraform.Options) {
+					clusterName := terraform.Output(t, o, "ecs_cluster_name")
+					assert.NotEmpty(t, clusterName)
+					assert.Contains(t, clusterName, projectName)
+				},
+				func(t *testing.T, o *terraform.Options) {
+					staticBucket := terraform.Output(t, o, "s3_static_bucket")
+					uploadsBucket := terraform.Output(t, o, "s3_uploads_bucket")
+
+					assert.NotEmpty(t, staticBucket)
+					assert.NotEmpty(t, uploadsBucket)
+
+					versioning := aws.GetS3BucketVersioning(t, awsRegion, uploadsBucket)
+					assert.Equal(t, "Enabled", versioning)
+				},
+				func(t *testing.T, o *terraform.Options) {
+					backendRepo := terraform.Output(t, o, "ecr_backend_url")
+					frontendRepo := terraform.Output(t, o, "ecr_frontend_url")
+
+					assert.NotEmpty(t, backendRepo)
+					assert.NotEmpty(t, frontendRepo)
+					assert.Contains(t, backendRepo, "backend")
+					assert.Contains(t, frontendRepo, "frontend")
+				},
+				func(t *testing.T, o *terraform.Options) {
+					kmsKeyID := terraform.Output(t, o, "kms_key_id")
+					assert.NotEmpty(t, kmsKeyID)
+
+					keyMetadata := aws.GetKmsKeyMetadata(t, awsRegion, kmsKeyID)
+					assert.Equal(t, true, *keyMetadata.KeyRotationEnabled)
+					previousKMSKeyID = kmsKeyID
+				},
+				func(t *testing.T, o *terraform.Options) {
+					logGroupName := terraform.Output(t, o, "cloudwatch_log_group")
+					assert.NotEmpty(t, logGroupName)
+
+					logGroup := aws.GetCloudWatchLogGroup(t, awsRegion, logGroupName)
+					assert.NotNil(t, logGroup.RetentionInDays)
+				},
+				func(t *testing.T, o *terraform.Options) {
+					autoscalingTargetID := terraform.Output(t, o, "autoscaling_target_id")
+					assert.NotEmpty(t, autoscalingTargetID)
+					previousAutoscalingTargetID = autoscalingTargetID
+				},
+				func(t *testing.T, o *terraform.Options) {
+					meshID := terraform.Output(t, o, "appmesh_mesh_id")
+					if meshID != "" {
+						assert.Contains(t, meshID, projectName)
+					}
+				},
+				func(t *testing.T, o *terraform.Options) {
+					secretArn := terraform.Output(t, o, "db_credentials_secret_arn")
+					assert.NotEmpty(t, secretArn)
+					assert.Contains(t, secretArn, "secretsmanager")
+				},
+				func(t *testing.T, o *terraform.Options) {
+					dashboardName := terraform.Output(t, o, "cloudwatch_dashboard_name")
+					assert.NotEmpty(t, dashboardName)
+					assert.Contains(t, dashboardName, "overview")
+				},
+			},
 		},
-	}
-
-	terraform.Init(t, terraformOptions)
-
-	// Run terraform plan and validate security resources
-	planExitCode := terraform.PlanExitCode(t, terraformOptions)
-	assert.Equal(t, 0, planExitCode)
+		{
+			Name: "ScaleUp",
+			Vars: map[string]interface{}{"ecs_min_capacity": 3},
+			Checks: []steps.CheckFunc{
+				func(t *testing.T, o *terraform.Options) {
+					autoscalingTargetID := terraform.Output(t, o, "autoscaling_target_id")
+					assert.Equal(t, previousAutoscalingTargetID, autoscalingTargetID,
+						"autoscaling target should be updated in-place when ecs_min_capacity changes, not replaced")
+				},
+			},
+		},
+		{
+			Name: "ToggleKMSEncryption",
+			Vars: map[string]interface{}{"enable_kms_encryption": false},
+			Checks: []steps.CheckFunc{
+				func(t *testing.T, o *terraform.Options) {
+					kmsKeyID := terraform.Output(t, o, "kms_key_id")
+					assert.NotEqual(t, previousKMSKeyID, kmsKeyID,
+						"toggling enable_kms_encryption should recreate (or remove) the KMS key, not leave it unchanged")
+				},
+			},
+		},
+	})
 }
 
 // TestTerraformModuleHighAvailability validates HA configurations
@@ -201,15 +222,17 @@ func TestTerraformModuleDisasterRecovery(t *testing.T) {
 }
 
 // TestTerraformModuleCostOptimization validates cost optimization settings
+// by pricing the plan with Infracost and enforcing real budget limits,
+// rather than only checking that the plan succeeds.
 func TestTerraformModuleCostOptimization(t *testing.T) {
 	t.Parallel()
 
 	terraformOptions := &terraform.Options{
 		TerraformDir: "../../terraform",
 		Vars: map[string]interface{}{
-			"db_instance_class":       "db.t3.micro",
-			"ecs_min_capacity":        1,
-			"enable_spot_instances":   true,
+			"db_instance_class":     "db.t3.micro",
+			"ecs_min_capacity":      1,
+			"enable_spot_instances": true,
 		},
 	}
 
@@ -217,4 +240,18 @@ func TestTerraformModuleCostOptimization(t *testing.T) {
 
 	planExitCode := terraform.PlanExitCode(t, terraformOptions)
 	assert.Equal(t, 0, planExitCode)
+
+	breakdown := cost.RunBreakdown(t, cost.Options{
+		TerraformDir: terraformOptions.TerraformDir,
+		Vars: []string{
+			"db_instance_class=db.t3.micro",
+			"ecs_min_capacity=1",
+			"enable_spot_instances=true",
+		},
+	})
+
+	cost.AssertMonthlyCostBelow(t, breakdown, 150.00)
+	cost.AssertResourceCostBelow(t, breakdown, "aws_db_instance.main", 30.00)
+	cost.AssertNoUnexpectedResourceTypes(t, breakdown, allowedCostedResourceTypes)
+	cost.AssertCostIncreaseBelowBaseline(t, breakdown, costBaselinePath, maxCostIncreasePercent)
 }
@@ -0,0 +1,51 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangsonww/petswipe-match-app/tests/policy"
+)
+
+// policyDir holds the bundled Rego policies evaluated by
+// TestTerraformPolicyCompliance, relative to this package.
+const policyDir = "../policies"
+
+// TestTerraformPolicyCompliance runs a Terraform plan, converts it to JSON
+// via `terraform show -json`, and evaluates every resource change against
+// the bundled CIS AWS benchmark policies (no public S3 ACLs, RDS encryption
+// at rest, KMS rotation, no 0.0.0.0/0 ingress on non-HTTP ports, IAM
+// wildcard actions, CloudWatch log retention >= 90 days). This supersedes
+// the former TestTerraformModuleSecurity, which only checked that plan
+// exits 0; this test fails with a grouped per-resource violation report
+// when a rule is broken.
+func TestTerraformPolicyCompliance(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := &terraform.Options{
+		TerraformDir: "../../terraform",
+		PlanFilePath: "tfplan",
+		Vars: map[string]interface{}{
+			"enable_waf":            true,
+			"enable_kms_encryption": true,
+			"enable_guardduty":      true,
+		},
+	}
+
+	planJSON := terraform.InitAndPlanAndShow(t, terraformOptions)
+
+	var plan map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(planJSON), &plan), "failed to parse terraform show -json output")
+
+	ctx := context.Background()
+	evaluator, err := policy.NewEvaluator(ctx, policyDir)
+	require.NoError(t, err)
+
+	report, err := evaluator.EvaluateResourceChanges(ctx, plan)
+	require.NoError(t, err)
+	require.True(t, report.Empty(), "policy compliance violations found:\n%s", report)
+}
@@ -0,0 +1,68 @@
+// Package steps provides a table-driven acceptance-test matrix runner
+// modeled on the Terraform AWS provider's resource.Test/TestStep pattern:
+// a single test declares an ordered slice of Steps, each applying a new set
+// of vars against one persistent workspace and asserting both resource
+// state and that a follow-up plan is empty.
+package steps
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// CheckFunc inspects the Terraform outputs/state after a Step's apply and
+// fails the test (via t) if something is wrong.
+type CheckFunc func(t *testing.T, options *terraform.Options)
+
+// Step is one entry in an acceptance-test matrix: a set of vars to apply,
+// a set of checks to run against the result, and whether a following plan
+// is expected to show changes.
+type Step struct {
+	// Name identifies the step in test output, e.g. t.Run(step.Name, ...).
+	Name string
+	// Vars are merged into the persistent workspace's vars and applied.
+	Vars map[string]interface{}
+	// Checks run against the workspace after apply succeeds.
+	Checks []CheckFunc
+	// ExpectNonEmptyPlan requires drift: when true, a follow-up
+	// `terraform plan` after this step's apply must show changes (exit
+	// code 2); when false (the default) it must be empty, proving the
+	// apply converged with no further changes.
+	ExpectNonEmptyPlan bool
+}
+
+// Run applies each Step in order against a single persistent workspace
+// rooted at options.TerraformDir, running that step's Checks after each
+// apply and asserting drift-free convergence unless the step opts out via
+// ExpectNonEmptyPlan. Terraform is initialized once before the first step
+// and destroyed once after the last, regardless of step outcome.
+func Run(t *testing.T, options *terraform.Options, testSteps []Step) {
+	t.Helper()
+
+	defer terraform.Destroy(t, options)
+	terraform.Init(t, options)
+
+	for _, step := range testSteps {
+		step := step
+		t.Run(step.Name, func(t *testing.T) {
+			for k, v := range step.Vars {
+				options.Vars[k] = v
+			}
+
+			terraform.Apply(t, options)
+
+			for _, check := range step.Checks {
+				check(t, options)
+			}
+
+			planExitCode := terraform.PlanExitCode(t, options)
+			if step.ExpectNonEmptyPlan {
+				assert.Equal(t, 2, planExitCode, "step %q: expected a non-empty follow-up plan", step.Name)
+			} else {
+				assert.Equal(t, 0, planExitCode, "step %q: follow-up plan is not empty, apply did not converge", step.Name)
+			}
+		})
+	}
+}